@@ -0,0 +1,216 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package releaseutil
+
+import (
+	"fmt"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+
+	"helm.sh/helm/v3/pkg/release"
+)
+
+// dependsOnAnno names one or more resources that a manifest or hook must be
+// installed after (and uninstalled before). Its value is a comma-separated
+// list of "Kind/name" or "Kind/namespace/name" references.
+const dependsOnAnno = "helm.sh/depends-on"
+
+// resourceRef identifies a manifest or hook by the triple that uniquely
+// names a Kubernetes object.
+type resourceRef struct {
+	Kind      string
+	Namespace string
+	Name      string
+}
+
+func (r resourceRef) String() string {
+	if r.Namespace == "" {
+		return fmt.Sprintf("%s/%s", r.Kind, r.Name)
+	}
+	return fmt.Sprintf("%s/%s/%s", r.Kind, r.Namespace, r.Name)
+}
+
+// DependencyCycleError is returned when the helm.sh/depends-on annotations
+// of a set of manifests and hooks do not form a DAG, so no topological
+// install/uninstall order exists.
+type DependencyCycleError struct {
+	// Resources lists every resource that could not be scheduled: the
+	// members of the cycle together with anything that depends on them,
+	// in the seeded Kind/weight order.
+	Resources []string
+}
+
+func (e *DependencyCycleError) Error() string {
+	return fmt.Sprintf("circular helm.sh/depends-on reference among: %s", strings.Join(e.Resources, ", "))
+}
+
+// parseDependsOn reads the helm.sh/depends-on annotation off head, if
+// present, resolving each "Kind/name" reference against head's own
+// namespace. References may also be fully qualified as
+// "Kind/namespace/name". Malformed entries are ignored, since they cannot
+// match any known resource.
+func parseDependsOn(head *SimpleHead) []resourceRef {
+	if head.Metadata == nil || head.Metadata.Annotations == nil {
+		return nil
+	}
+	raw, ok := head.Metadata.Annotations[dependsOnAnno]
+	if !ok || strings.TrimSpace(raw) == "" {
+		return nil
+	}
+
+	var refs []resourceRef
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		switch segs := strings.Split(part, "/"); len(segs) {
+		case 2:
+			refs = append(refs, resourceRef{Kind: segs[0], Namespace: head.Metadata.Namespace, Name: segs[1]})
+		case 3:
+			refs = append(refs, resourceRef{Kind: segs[0], Namespace: segs[1], Name: segs[2]})
+		}
+	}
+	return refs
+}
+
+// dependencySorter refines a Kind/weight-ordered sequence into a topological
+// order using helm.sh/depends-on edges, falling back to the incoming order
+// to break ties and seed determinism.
+//
+// It runs a stable variant of Kahn's algorithm: repeated passes over the
+// current order, each pass scheduling the first node in the seeded order
+// whose dependencies have all been scheduled. Scanning from the start of the
+// seeded order on every pass means ties are always broken by the Kind/weight
+// order already applied by sortManifestsByKind. This is O(n^2) in the worst
+// case, which is acceptable for the number of resources in a typical chart.
+type dependencySorter struct {
+	refs      []resourceRef   // ref for each position in the seeded order
+	dependsOn [][]resourceRef // dependencies for each position
+	ascending bool            // true: dependency before dependent; false: reversed
+}
+
+// order returns a permutation of [0, n) describing the schedule, or a
+// *DependencyCycleError if the dependencies are cyclic.
+func (d *dependencySorter) order() ([]int, error) {
+	n := len(d.refs)
+
+	index := make(map[resourceRef]int, n)
+	for i, r := range d.refs {
+		index[r] = i
+	}
+
+	// indegree/successors are expressed in "must come before" terms: for the
+	// install direction that's the dependency; for uninstall it's flipped so
+	// that dependents are scheduled before the resources they depend on.
+	indegree := make([]int, n)
+	successors := make([][]int, n)
+	for dependent, deps := range d.dependsOn {
+		for _, dep := range deps {
+			depender, ok := index[dep]
+			if !ok || depender == dependent {
+				continue
+			}
+			before, after := depender, dependent
+			if !d.ascending {
+				before, after = after, before
+			}
+			successors[before] = append(successors[before], after)
+			indegree[after]++
+		}
+	}
+
+	scheduled := make([]bool, n)
+	order := make([]int, 0, n)
+	for len(order) < n {
+		progressed := false
+		for i := 0; i < n; i++ {
+			if scheduled[i] || indegree[i] > 0 {
+				continue
+			}
+			scheduled[i] = true
+			order = append(order, i)
+			progressed = true
+			for _, next := range successors[i] {
+				indegree[next]--
+			}
+			break
+		}
+		if !progressed {
+			var cycle []string
+			for i := 0; i < n; i++ {
+				if !scheduled[i] {
+					cycle = append(cycle, d.refs[i].String())
+				}
+			}
+			return nil, &DependencyCycleError{Resources: cycle}
+		}
+	}
+	return order, nil
+}
+
+// sortManifestsByDependency refines manifests (already ordered by
+// sortManifestsByKind) into a topological order honoring any
+// helm.sh/depends-on annotations.
+func sortManifestsByDependency(manifests []Manifest, ascending bool) ([]Manifest, error) {
+	refs := make([]resourceRef, len(manifests))
+	deps := make([][]resourceRef, len(manifests))
+	for i, m := range manifests {
+		refs[i] = resourceRef{Kind: m.Head.Kind, Namespace: namespaceOf(m.Head), Name: nameOf(m.Head)}
+		deps[i] = m.DependsOn
+	}
+
+	order, err := (&dependencySorter{refs: refs, dependsOn: deps, ascending: ascending}).order()
+	if err != nil {
+		return nil, err
+	}
+
+	sorted := make([]Manifest, len(manifests))
+	for i, idx := range order {
+		sorted[i] = manifests[idx]
+	}
+	return sorted, nil
+}
+
+// sortHooksByDependency refines hooks (already ordered by sortHooksByKind)
+// into a topological order honoring any helm.sh/depends-on annotations found
+// on the originating manifest. deps maps each hook to the references it
+// depends on; hooks with no entry are assumed to have none.
+func sortHooksByDependency(hooks []*release.Hook, deps map[*release.Hook][]resourceRef, ascending bool) ([]*release.Hook, error) {
+	refs := make([]resourceRef, len(hooks))
+	dependsOn := make([][]resourceRef, len(hooks))
+	for i, h := range hooks {
+		// The namespace is read off the hook's own manifest, since
+		// parseDependsOn resolves "Kind/name" against that namespace.
+		var head SimpleHead
+		_ = yaml.Unmarshal([]byte(h.Manifest), &head)
+		refs[i] = resourceRef{Kind: h.Kind, Namespace: namespaceOf(&head), Name: h.Name}
+		dependsOn[i] = deps[h]
+	}
+
+	order, err := (&dependencySorter{refs: refs, dependsOn: dependsOn, ascending: ascending}).order()
+	if err != nil {
+		return nil, err
+	}
+
+	sorted := make([]*release.Hook, len(hooks))
+	for i, idx := range order {
+		sorted[i] = hooks[idx]
+	}
+	return sorted, nil
+}
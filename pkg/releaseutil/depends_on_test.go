@@ -0,0 +1,123 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package releaseutil
+
+import (
+	"testing"
+
+	"helm.sh/helm/v3/pkg/chartutil"
+)
+
+func TestSortManifestsHonorsDependsOn(t *testing.T) {
+	// "front" depends on "back". Kind/weight ordering alone already places
+	// back before front (ties are broken by file name) for both install and
+	// uninstall, so the uninstall half below is where the dependency edge
+	// actually reorders the Deployments.
+	front := `apiVersion: v1
+kind: Deployment
+metadata:
+  name: front
+  annotations:
+    "helm.sh/depends-on": "Deployment/back"`
+
+	back := `apiVersion: v1
+kind: Deployment
+metadata:
+  name: back`
+
+	input := map[string]string{
+		"front": front,
+		"back":  back,
+	}
+
+	_, manifests, err := SortManifests(input, chartutil.VersionSet{"v1"}, InstallOrder)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if len(manifests) != 2 {
+		t.Fatalf("Expected 2 manifests, got %d", len(manifests))
+	}
+	if manifests[0].Head.Metadata.Name != "back" || manifests[1].Head.Metadata.Name != "front" {
+		t.Fatalf("Expected [back, front], got [%s, %s]", manifests[0].Head.Metadata.Name, manifests[1].Head.Metadata.Name)
+	}
+
+	// Uninstall order must reverse the dependency order: "front" (the
+	// dependent) goes first, "back" (the dependency) goes last.
+	_, manifestsUninstall, err := SortManifests(input, chartutil.VersionSet{"v1"}, UninstallOrder)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if manifestsUninstall[0].Head.Metadata.Name != "front" || manifestsUninstall[1].Head.Metadata.Name != "back" {
+		t.Fatalf("Expected [front, back], got [%s, %s]", manifestsUninstall[0].Head.Metadata.Name, manifestsUninstall[1].Head.Metadata.Name)
+	}
+}
+
+func TestSortManifestsDetectsDependsOnCycle(t *testing.T) {
+	a := `apiVersion: v1
+kind: Deployment
+metadata:
+  name: a
+  annotations:
+    "helm.sh/depends-on": "Deployment/b"`
+
+	b := `apiVersion: v1
+kind: Deployment
+metadata:
+  name: b
+  annotations:
+    "helm.sh/depends-on": "Deployment/a"`
+
+	input := map[string]string{"a": a, "b": b}
+
+	_, _, err := SortManifests(input, chartutil.VersionSet{"v1"}, InstallOrder)
+	if err == nil {
+		t.Fatal("Expected a DependencyCycleError, got nil")
+	}
+	if _, ok := err.(*DependencyCycleError); !ok {
+		t.Fatalf("Expected a *DependencyCycleError, got %T: %s", err, err)
+	}
+}
+
+func TestParseDependsOn(t *testing.T) {
+	head := &SimpleHead{
+		Metadata: &struct {
+			Name        string            `json:"name,omitempty"`
+			Namespace   string            `json:"namespace,omitempty"`
+			Annotations map[string]string `json:"annotations,omitempty"`
+		}{
+			Namespace: "ns",
+			Annotations: map[string]string{
+				dependsOnAnno: "ServiceAccount/sa, Secret/other-ns/s, , bogus",
+			},
+		},
+	}
+
+	got := parseDependsOn(head)
+	want := []resourceRef{
+		{Kind: "ServiceAccount", Namespace: "ns", Name: "sa"},
+		{Kind: "Secret", Namespace: "other-ns", Name: "s"},
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("Expected %d refs, got %d: %v", len(want), len(got), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("ref %d: expected %v, got %v", i, want[i], got[i])
+		}
+	}
+}
@@ -0,0 +1,132 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package releaseutil
+
+import (
+	"strconv"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/release"
+)
+
+// helm.sh/hook is the label that marks a resource as part of a hook and
+// identifies which hook events it should fire on.
+const hookAnno = "helm.sh/hook"
+
+// helm.sh/hook-weight controls the firing order of hooks with the same event.
+// Hooks are fired in ascending weight order.
+const hookWeightAnno = "helm.sh/hook-weight"
+
+// helm.sh/hook-delete-policy controls when a hook resource is deleted.
+const hookDeleteAnno = "helm.sh/hook-delete-policy"
+
+// helm.sh/weight controls the install/uninstall ordering of regular (non-hook)
+// manifests; for KindSortOrder it is compared before the Kind-based ordering.
+const weightAnno = "helm.sh/weight"
+
+// events maps the string value of the helm.sh/hook annotation to the
+// corresponding release.HookEvent.
+var events = map[string]release.HookEvent{
+	"pre-install":   release.HookPreInstall,
+	"post-install":  release.HookPostInstall,
+	"pre-delete":    release.HookPreDelete,
+	"post-delete":   release.HookPostDelete,
+	"pre-upgrade":   release.HookPreUpgrade,
+	"post-upgrade":  release.HookPostUpgrade,
+	"pre-rollback":  release.HookPreRollback,
+	"post-rollback": release.HookPostRollback,
+	"test":          release.HookTest,
+	"test-success":  release.HookTest,
+}
+
+// deletePolices maps the string value of the helm.sh/hook-delete-policy
+// annotation to the corresponding release.HookDeletePolicy.
+var deletePolices = map[string]release.HookDeletePolicy{
+	"hook-succeeded":       release.HookSucceeded,
+	"hook-failed":          release.HookFailed,
+	"before-hook-creation": release.HookBeforeHookCreation,
+}
+
+// SimpleHead is a parse-only representation of the TypeMeta and metadata
+// needed to decide how a manifest should be sorted/hooked.
+type SimpleHead struct {
+	Version  string `json:"apiVersion"`
+	Kind     string `json:"kind"`
+	Metadata *struct {
+		Name        string            `json:"name,omitempty"`
+		Namespace   string            `json:"namespace,omitempty"`
+		Annotations map[string]string `json:"annotations,omitempty"`
+	} `json:"metadata,omitempty"`
+}
+
+// namespaceOf returns head's namespace, or "" if it has none.
+func namespaceOf(head *SimpleHead) string {
+	if head.Metadata == nil {
+		return ""
+	}
+	return head.Metadata.Namespace
+}
+
+// nameOf returns head's name, or "" if it has none.
+func nameOf(head *SimpleHead) string {
+	if head.Metadata == nil {
+		return ""
+	}
+	return head.Metadata.Name
+}
+
+// calculateHookWeight parses the helm.sh/hook-weight annotation on head,
+// defaulting to 0 when absent or unparsable.
+func calculateHookWeight(head SimpleHead) int {
+	hws := head.Metadata.Annotations[hookWeightAnno]
+	hw, err := strconv.Atoi(hws)
+	if err != nil {
+		hw = 0
+	}
+	return hw
+}
+
+// calculateWeight parses the helm.sh/weight annotation on head, defaulting to
+// 0 when absent or unparsable.
+func calculateWeight(head *SimpleHead) int {
+	if head.Metadata == nil || head.Metadata.Annotations == nil {
+		return 0
+	}
+	ws := head.Metadata.Annotations[weightAnno]
+	w, err := strconv.Atoi(ws)
+	if err != nil {
+		return 0
+	}
+	return w
+}
+
+// parseDeletePolicies splits and resolves the helm.sh/hook-delete-policy
+// annotation value into a list of release.HookDeletePolicy. Unrecognized
+// values are ignored.
+func parseDeletePolicies(policies string) []release.HookDeletePolicy {
+	out := []release.HookDeletePolicy{}
+	for _, p := range strings.Split(policies, ",") {
+		p = strings.ToLower(strings.TrimSpace(p))
+		if p == "" {
+			continue
+		}
+		if dp, ok := deletePolices[p]; ok {
+			out = append(out, dp)
+		}
+	}
+	return out
+}
@@ -0,0 +1,243 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package releaseutil
+
+import (
+	"sort"
+
+	"helm.sh/helm/v3/pkg/release"
+)
+
+// KindSortOrder describes the direction in which the helm.sh/weight
+// annotation orders manifests during install/uninstall, and how manifests of
+// different Kinds are interleaved when their weights are equal.
+type KindSortOrder struct {
+	// Kinds lists the Kind ordering, earliest first. Kinds that do not
+	// appear in the list sort after all listed Kinds.
+	Kinds []string
+	// Ascending is true when manifests should be sorted by ascending
+	// helm.sh/weight (install), and false for descending (uninstall).
+	Ascending bool
+}
+
+// index returns the position of kind in the order, and whether it was found.
+func (o KindSortOrder) index(kind string) (int, bool) {
+	for i, k := range o.Kinds {
+		if k == kind {
+			return i, true
+		}
+	}
+	return -1, false
+}
+
+// Less implements ManifestSorter: ties on helm.sh/weight are broken by Kinds
+// (Kinds not listed in the order sort after all listed Kinds), then by Name.
+func (o KindSortOrder) Less(a, b Manifest) bool {
+	if a.Weight != b.Weight {
+		if o.Ascending {
+			return a.Weight < b.Weight
+		}
+		return a.Weight > b.Weight
+	}
+
+	aIdx, aok := o.index(a.Head.Kind)
+	bIdx, bok := o.index(b.Head.Kind)
+	if aok != bok {
+		return aok
+	}
+	if aok && aIdx != bIdx {
+		return aIdx < bIdx
+	}
+	return a.Name < b.Name
+}
+
+// Group implements ManifestSorter, using the coarse Kind groupings in
+// kindGroups rather than o.Kinds, so that custom Kind orderings still get
+// the same concurrency-safety boundaries as the built-in orders.
+func (o KindSortOrder) Group(m Manifest) int {
+	return kindGroupOf(m.Head.Kind)
+}
+
+// ReverseDependencies implements DirectionalSorter: uninstall orders
+// (Ascending == false) uninstall dependents before the resources they
+// depend on, the reverse of install order.
+func (o KindSortOrder) ReverseDependencies() bool {
+	return !o.Ascending
+}
+
+// InstallOrder is the order in which manifests should be installed (by Kind).
+//
+// Those occurring earlier in the list get installed before those later in
+// the list.
+var InstallOrder = KindSortOrder{
+	Ascending: true,
+	Kinds: []string{
+		"Namespace",
+		"NetworkPolicy",
+		"ResourceQuota",
+		"LimitRange",
+		"PodSecurityPolicy",
+		"PodDisruptionBudget",
+		"ServiceAccount",
+		"Secret",
+		"SecretList",
+		"ConfigMap",
+		"StorageClass",
+		"PersistentVolume",
+		"PersistentVolumeClaim",
+		"CustomResourceDefinition",
+		"ClusterRole",
+		"ClusterRoleList",
+		"ClusterRoleBinding",
+		"ClusterRoleBindingList",
+		"Role",
+		"RoleList",
+		"RoleBinding",
+		"RoleBindingList",
+		"Service",
+		"DaemonSet",
+		"Pod",
+		"ReplicationController",
+		"ReplicaSet",
+		"Deployment",
+		"HorizontalPodAutoscaler",
+		"StatefulSet",
+		"Job",
+		"CronJob",
+		"IngressClass",
+		"Ingress",
+		"APIService",
+	},
+}
+
+// UninstallOrder is the order in which manifests should be uninstalled (by
+// Kind).
+//
+// Those occurring earlier in the list get uninstalled before those later in
+// the list.
+var UninstallOrder = KindSortOrder{
+	Ascending: false,
+	Kinds: []string{
+		"APIService",
+		"Ingress",
+		"IngressClass",
+		"Service",
+		"CronJob",
+		"Job",
+		"StatefulSet",
+		"HorizontalPodAutoscaler",
+		"Deployment",
+		"ReplicaSet",
+		"ReplicationController",
+		"Pod",
+		"DaemonSet",
+		"RoleBindingList",
+		"RoleBinding",
+		"RoleList",
+		"Role",
+		"ClusterRoleBindingList",
+		"ClusterRoleBinding",
+		"ClusterRoleList",
+		"ClusterRole",
+		"CustomResourceDefinition",
+		"PersistentVolumeClaim",
+		"PersistentVolume",
+		"StorageClass",
+		"ConfigMap",
+		"SecretList",
+		"Secret",
+		"ServiceAccount",
+		"PodDisruptionBudget",
+		"PodSecurityPolicy",
+		"LimitRange",
+		"ResourceQuota",
+		"NetworkPolicy",
+		"Namespace",
+	},
+}
+
+// sortManifestsByKind sorts manifests according to sorter. The sort is
+// stable, so manifests the sorter considers equal keep their relative input
+// order.
+func sortManifestsByKind(manifests []Manifest, sorter ManifestSorter) []Manifest {
+	ms := &manifestSortAdapter{manifests: manifests, sorter: sorter}
+	sort.Stable(ms)
+	return ms.manifests
+}
+
+// manifestSortAdapter adapts a ManifestSorter to sort.Interface.
+type manifestSortAdapter struct {
+	sorter    ManifestSorter
+	manifests []Manifest
+}
+
+func (a *manifestSortAdapter) Len() int { return len(a.manifests) }
+
+func (a *manifestSortAdapter) Swap(i, j int) {
+	a.manifests[i], a.manifests[j] = a.manifests[j], a.manifests[i]
+}
+
+func (a *manifestSortAdapter) Less(i, j int) bool {
+	return a.sorter.Less(a.manifests[i], a.manifests[j])
+}
+
+// sortHooksByKind applies the same ordering used for manifests to hooks, by
+// comparing the synthetic Manifest each hook converts to via hookAsManifest.
+func sortHooksByKind(hooks []*release.Hook, sorter ManifestSorter) []*release.Hook {
+	hs := &hookSortAdapter{hooks: hooks, sorter: sorter}
+	for _, h := range hooks {
+		hs.manifests = append(hs.manifests, hookAsManifest(h))
+	}
+	sort.Stable(hs)
+	return hs.hooks
+}
+
+// hookSortAdapter adapts a ManifestSorter to sort.Interface for hooks,
+// keeping each hook's synthetic Manifest (used for comparisons) in lockstep.
+type hookSortAdapter struct {
+	sorter    ManifestSorter
+	hooks     []*release.Hook
+	manifests []Manifest
+}
+
+func (h *hookSortAdapter) Len() int { return len(h.hooks) }
+
+func (h *hookSortAdapter) Swap(i, j int) {
+	h.hooks[i], h.hooks[j] = h.hooks[j], h.hooks[i]
+	h.manifests[i], h.manifests[j] = h.manifests[j], h.manifests[i]
+}
+
+func (h *hookSortAdapter) Less(i, j int) bool {
+	return h.sorter.Less(h.manifests[i], h.manifests[j])
+}
+
+// hookAsManifest builds the synthetic Manifest used to compare h against
+// other hooks and manifests via ManifestSorter.
+func hookAsManifest(h *release.Hook) Manifest {
+	return Manifest{
+		Name:   h.Name,
+		Weight: h.Weight,
+		Head: &SimpleHead{
+			Kind: h.Kind,
+			Metadata: &struct {
+				Name        string            `json:"name,omitempty"`
+				Namespace   string            `json:"namespace,omitempty"`
+				Annotations map[string]string `json:"annotations,omitempty"`
+			}{Name: h.Name},
+		},
+	}
+}
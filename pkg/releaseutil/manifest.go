@@ -0,0 +1,31 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package releaseutil
+
+// Manifest represents a manifest file, which has a name and some content.
+type Manifest struct {
+	Name    string
+	Content string
+	Head    *SimpleHead
+	Weight  int // From helm.sh/weight; the ManifestSorter decides its direction.
+
+	// DependsOn lists the resources referenced by this manifest's
+	// helm.sh/depends-on annotation, if any. It is consulted by
+	// sortManifestsByDependency to refine the Kind/weight order computed by
+	// sortManifestsByKind into a topological order.
+	DependsOn []resourceRef
+}
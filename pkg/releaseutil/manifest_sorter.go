@@ -0,0 +1,216 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package releaseutil
+
+import (
+	"fmt"
+	"log"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/release"
+)
+
+var sep = regexp.MustCompile("(?:^|\\s*\n)---\\s*")
+
+// manifestFile represents a file that contains one or more YAML documents,
+// each of which is either a hook or a generic manifest.
+type manifestFile struct {
+	entries map[string]string
+	path    string
+	apis    chartutil.VersionSet
+}
+
+// result gathers the hooks and generic manifests found while walking the
+// files passed to SortManifests.
+type result struct {
+	hooks   []*release.Hook
+	generic []Manifest
+
+	// hookDeps records the helm.sh/depends-on references declared by each
+	// hook. It's kept out-of-band because release.Hook has no field for it.
+	hookDeps map[*release.Hook][]resourceRef
+}
+
+// SplitManifests takes a string of manifests and returns a map of individual
+// manifests, keyed by an index-derived name that preserves their relative
+// order within the original string.
+func SplitManifests(bigFile string) map[string]string {
+	tpl := "manifest-%d"
+	res := map[string]string{}
+	// Making sure that any extra whitespace in YAML stream doesn't interfere
+	// in splitting documents correctly.
+	bigFileTmp := strings.TrimSpace(bigFile)
+	docs := sep.Split(bigFileTmp, -1)
+	var count int
+	for _, d := range docs {
+		if d == "" {
+			continue
+		}
+
+		d = strings.TrimSpace(d)
+		res[fmt.Sprintf(tpl, count)] = d
+		count++
+	}
+	return res
+}
+
+// SortManifests parses a map of filename/YAML content pairs and sorts them
+// into hooks and generic manifests. Hooks are extracted based on the
+// helm.sh/hook annotation; everything else is returned as a Manifest.
+//
+// The result is ordered by sorter's rules first, then refined into a
+// topological order honoring any helm.sh/depends-on annotations (see
+// DependencyCycleError); if sorter also implements DirectionalSorter, its
+// ReverseDependencies decides whether those edges run forward or in
+// reverse. Hooks and manifests are ordered independently of one another,
+// since they execute at different points in the release lifecycle; a
+// dependency reference that only matches an item in the other list has no
+// effect.
+//
+// Files whose basename starts with "_" and files with no content are skipped.
+func SortManifests(files map[string]string, apis chartutil.VersionSet, sorter ManifestSorter) ([]*release.Hook, []Manifest, error) {
+	result := &result{hookDeps: map[*release.Hook][]resourceRef{}}
+
+	var sortedFilePaths []string
+	for filePath := range files {
+		sortedFilePaths = append(sortedFilePaths, filePath)
+	}
+	sort.Strings(sortedFilePaths)
+
+	for _, filePath := range sortedFilePaths {
+		content := files[filePath]
+
+		// Skip partials. We could return these as a separate map, but there
+		// doesn't seem to be any need for that at this time.
+		if strings.HasPrefix(path.Base(filePath), "_") {
+			continue
+		}
+		// Skip empty files.
+		if strings.TrimSpace(content) == "" {
+			continue
+		}
+
+		manifestFile := &manifestFile{
+			entries: SplitManifests(content),
+			path:    filePath,
+			apis:    apis,
+		}
+
+		if err := manifestFile.sort(result); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	ascending := true
+	if ds, ok := sorter.(DirectionalSorter); ok {
+		ascending = !ds.ReverseDependencies()
+	}
+
+	hooks, err := sortHooksByDependency(sortHooksByKind(result.hooks, sorter), result.hookDeps, ascending)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	manifests, err := sortManifestsByDependency(sortManifestsByKind(result.generic, sorter), ascending)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return hooks, manifests, nil
+}
+
+// sort classifies each entry in the file as a hook or generic manifest and
+// appends it to result, in the order entries appear in the source file.
+func (file *manifestFile) sort(result *result) error {
+	var entryNames []string
+	for name := range file.entries {
+		entryNames = append(entryNames, name)
+	}
+	// Names come from SplitManifests ("manifest-0", "manifest-1", ...), so
+	// comparing length first keeps "manifest-10" after "manifest-9".
+	sort.Slice(entryNames, func(i, j int) bool {
+		if len(entryNames[i]) != len(entryNames[j]) {
+			return len(entryNames[i]) < len(entryNames[j])
+		}
+		return entryNames[i] < entryNames[j]
+	})
+
+	for _, name := range entryNames {
+		m := file.entries[name]
+
+		var head SimpleHead
+		if err := yaml.Unmarshal([]byte(m), &head); err != nil {
+			return fmt.Errorf("parsing %s: %s", file.path, err)
+		}
+
+		if head.Kind == "" {
+			continue
+		}
+
+		if !file.apis.Has(head.Version) {
+			return fmt.Errorf("unable to recognize %q: no matches for kind %q, apiVersion %q", file.path, head.Kind, head.Version)
+		}
+
+		if head.Metadata == nil || len(head.Metadata.Annotations) == 0 {
+			result.generic = append(result.generic, Manifest{Name: file.path, Content: m, Head: &head})
+			continue
+		}
+
+		hookTypes, ok := head.Metadata.Annotations[hookAnno]
+		if !ok {
+			result.generic = append(result.generic, Manifest{Name: file.path, Content: m, Head: &head, Weight: calculateWeight(&head), DependsOn: parseDependsOn(&head)})
+			continue
+		}
+
+		hook := &release.Hook{
+			Name:           head.Metadata.Name,
+			Kind:           head.Kind,
+			Path:           file.path,
+			Manifest:       m,
+			Weight:         calculateHookWeight(head),
+			Events:         []release.HookEvent{},
+			DeletePolicies: parseDeletePolicies(head.Metadata.Annotations[hookDeleteAnno]),
+		}
+
+		isUnknownHook := false
+		for _, hookType := range strings.Split(hookTypes, ",") {
+			hookType = strings.ToLower(strings.TrimSpace(hookType))
+			event, ok := events[hookType]
+			if !ok {
+				isUnknownHook = true
+				break
+			}
+			hook.Events = append(hook.Events, event)
+		}
+		if isUnknownHook {
+			log.Printf("info: skipping unknown hook: %q", hookTypes)
+			continue
+		}
+
+		result.hooks = append(result.hooks, hook)
+		if deps := parseDependsOn(&head); len(deps) > 0 {
+			result.hookDeps[hook] = deps
+		}
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package releaseutil
+
+// ManifestSorter determines the order SortManifests installs or uninstalls
+// manifests and hooks in, and the concurrency-safety grouping
+// SortManifestsIntoPhases partitions them into. KindSortOrder (with
+// CustomKindOrder built on it) and AlphabeticalOrder are the built-in
+// implementations; operators and downstream tools (helm-diff,
+// Argo CD-style renderers) can supply their own to plug in an ordering
+// without forking this package.
+type ManifestSorter interface {
+	// Less reports whether a should be ordered before b.
+	Less(a, b Manifest) bool
+	// Group returns m's concurrency-safety group for SortManifestsIntoPhases:
+	// manifests in different groups are never placed in the same phase.
+	// Group is otherwise just an opaque identifier, not a total order.
+	Group(m Manifest) int
+}
+
+// DirectionalSorter is implemented by ManifestSorter strategies that have an
+// inherent install/uninstall direction, so that SortManifests knows whether
+// to resolve helm.sh/depends-on edges forward (install: dependency before
+// dependent) or in reverse (uninstall: dependent before dependency).
+// Strategies with no such direction (e.g. AlphabeticalOrder) are treated as
+// forward-only, since they're generally used for diffing rather than driving
+// an actual install or uninstall.
+type DirectionalSorter interface {
+	ManifestSorter
+	// ReverseDependencies reports whether helm.sh/depends-on edges should be
+	// resolved in reverse of their natural, install-time direction.
+	ReverseDependencies() bool
+}
+
+// AlphabeticalOrder sorts manifests by Kind, then by resource Name, ignoring
+// helm.sh/weight. It's useful for tools that want a deterministic order for
+// diffing a chart's rendered output across runs or revisions, independent of
+// weight annotations that may shift between chart versions.
+type AlphabeticalOrder struct{}
+
+// Less implements ManifestSorter.
+func (AlphabeticalOrder) Less(a, b Manifest) bool {
+	if a.Head.Kind != b.Head.Kind {
+		return a.Head.Kind < b.Head.Kind
+	}
+	return nameOf(a.Head) < nameOf(b.Head)
+}
+
+// Group implements ManifestSorter, reusing the same coarse Kind groupings as
+// KindSortOrder.
+func (AlphabeticalOrder) Group(m Manifest) int {
+	return kindGroupOf(m.Head.Kind)
+}
+
+// CustomKindOrder is a ManifestSorter built from a caller-provided Kind
+// ordering, for operators whose install order needs differ from the
+// built-in InstallOrder/UninstallOrder. It otherwise behaves exactly like
+// KindSortOrder: weight is compared first, Kind breaks ties on weight (Kinds
+// not listed sort after all listed Kinds), and Name breaks ties within a Kind.
+type CustomKindOrder struct {
+	KindSortOrder
+}
+
+// NewCustomKindOrder returns a CustomKindOrder that installs Kinds in the
+// given order, with Ascending set: helm.sh/weight sorts ascending and
+// helm.sh/depends-on edges resolve forward. Building one from a reversed
+// list reverses only the Kind order; weight and dependency direction stay
+// those of an install, unlike UninstallOrder.
+func NewCustomKindOrder(kinds []string) CustomKindOrder {
+	return CustomKindOrder{KindSortOrder{Kinds: kinds, Ascending: true}}
+}
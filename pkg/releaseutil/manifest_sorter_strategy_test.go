@@ -0,0 +1,86 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package releaseutil
+
+import (
+	"testing"
+
+	"helm.sh/helm/v3/pkg/chartutil"
+)
+
+func TestAlphabeticalOrderIgnoresWeight(t *testing.T) {
+	deployB := `apiVersion: v1
+kind: Deployment
+metadata:
+  name: b
+  annotations:
+    "helm.sh/weight": "-5"`
+
+	deployA := `apiVersion: v1
+kind: Deployment
+metadata:
+  name: a`
+
+	configMap := `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: only`
+
+	input := map[string]string{"deploy-b": deployB, "deploy-a": deployA, "cm": configMap}
+
+	_, manifests, err := SortManifests(input, chartutil.VersionSet{"v1"}, AlphabeticalOrder{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	var names []string
+	for _, m := range manifests {
+		names = append(names, m.Head.Kind+"/"+m.Head.Metadata.Name)
+	}
+	want := []string{"ConfigMap/only", "Deployment/a", "Deployment/b"}
+	if len(names) != len(want) {
+		t.Fatalf("expected %v, got %v", want, names)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, names)
+		}
+	}
+}
+
+func TestCustomKindOrder(t *testing.T) {
+	deployment := `apiVersion: v1
+kind: Deployment
+metadata:
+  name: d`
+
+	service := `apiVersion: v1
+kind: Service
+metadata:
+  name: s`
+
+	input := map[string]string{"deployment": deployment, "service": service}
+
+	sorter := NewCustomKindOrder([]string{"Deployment", "Service"})
+	_, manifests, err := SortManifests(input, chartutil.VersionSet{"v1"}, sorter)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if manifests[0].Head.Kind != "Deployment" || manifests[1].Head.Kind != "Service" {
+		t.Fatalf("Expected [Deployment, Service], got [%s, %s]", manifests[0].Head.Kind, manifests[1].Head.Kind)
+	}
+}
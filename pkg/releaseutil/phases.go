@@ -0,0 +1,151 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package releaseutil
+
+import (
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/release"
+)
+
+// kindGroups partitions well-known Kinds into coarse stages that are safe to
+// apply concurrently within a stage but must be applied in stage order
+// across stages. The stages are listed in the same order as InstallOrder's
+// Kinds (Namespace, then namespaced policy, then the default ServiceAccount,
+// then secrets/storage, then CustomResourceDefinition, then RBAC, then
+// workloads), so that Group's boundaries line up with where InstallOrder
+// actually cuts, rather than an idealized ordering of its own. Kinds not
+// listed here fall into a trailing group of their own. It backs the Group
+// method of the built-in ManifestSorter implementations.
+var kindGroups = [][]string{
+	{"Namespace"},
+	{"NetworkPolicy", "ResourceQuota", "LimitRange", "PodSecurityPolicy", "PodDisruptionBudget"},
+	{"ServiceAccount"},
+	{"Secret", "SecretList", "ConfigMap", "StorageClass", "PersistentVolume", "PersistentVolumeClaim"},
+	{"CustomResourceDefinition"},
+	{
+		"ClusterRole", "ClusterRoleList", "ClusterRoleBinding", "ClusterRoleBindingList",
+		"Role", "RoleList", "RoleBinding", "RoleBindingList",
+	},
+	{
+		"Service", "DaemonSet", "Pod", "ReplicationController", "ReplicaSet", "Deployment",
+		"HorizontalPodAutoscaler", "StatefulSet", "Job", "CronJob", "IngressClass", "Ingress", "APIService",
+	},
+}
+
+// kindGroupOf returns kind's position in kindGroups, or len(kindGroups) if
+// kind isn't listed in any group.
+func kindGroupOf(kind string) int {
+	for i, group := range kindGroups {
+		for _, k := range group {
+			if k == kind {
+				return i
+			}
+		}
+	}
+	return len(kindGroups)
+}
+
+// SortManifestsIntoPhases parses, sorts and partitions files the same way
+// SortManifests does, then further splits the result into phases: every
+// manifest (and, independently, every hook) within a phase can be applied to
+// the cluster concurrently, but phases must be applied in order.
+//
+// A new phase starts whenever the helm.sh/weight changes, whenever sorter's
+// Group changes (for the built-in orders, this follows InstallOrder's own
+// Kind sequence — see kindGroups), or whenever a manifest's
+// helm.sh/depends-on annotation references another manifest already placed
+// in the still-open phase, or is referenced by one (as in uninstall orders,
+// where dependents come first). Within those constraints, phases are
+// otherwise as large as possible, to maximize the work that can be done in
+// parallel. Hook phases are cut on weight and Group alone: a
+// helm.sh/depends-on reference between two hooks does not start a new hook
+// phase, so hooks in one phase may depend on each other.
+func SortManifestsIntoPhases(files map[string]string, apis chartutil.VersionSet, sorter ManifestSorter) ([][]Manifest, [][]*release.Hook, error) {
+	hooks, manifests, err := SortManifests(files, apis, sorter)
+	if err != nil {
+		return nil, nil, err
+	}
+	return phaseManifests(manifests, sorter), phaseHooks(hooks, sorter), nil
+}
+
+// phaseManifests splits manifests (already ordered by SortManifests) into
+// phases, cutting on weight, sorter.Group, and helm.sh/depends-on boundaries.
+func phaseManifests(manifests []Manifest, sorter ManifestSorter) [][]Manifest {
+	var phases [][]Manifest
+	var current []Manifest
+	currentRefs := map[resourceRef]bool{}
+	currentDeps := map[resourceRef]bool{}
+
+	for _, m := range manifests {
+		ref := resourceRef{Kind: m.Head.Kind, Namespace: namespaceOf(m.Head), Name: nameOf(m.Head)}
+		if len(current) > 0 && (manifestStartsNewPhase(current[len(current)-1], m, sorter, currentRefs) || currentDeps[ref]) {
+			phases = append(phases, current)
+			current = nil
+			currentRefs = map[resourceRef]bool{}
+			currentDeps = map[resourceRef]bool{}
+		}
+		current = append(current, m)
+		currentRefs[ref] = true
+		for _, dep := range m.DependsOn {
+			currentDeps[dep] = true
+		}
+	}
+	if len(current) > 0 {
+		phases = append(phases, current)
+	}
+	return phases
+}
+
+// manifestStartsNewPhase reports whether m must start a new phase given prev,
+// the last manifest placed in the still-open phase, and currentRefs, the
+// resources already placed in that phase.
+func manifestStartsNewPhase(prev, m Manifest, sorter ManifestSorter, currentRefs map[resourceRef]bool) bool {
+	if m.Weight != prev.Weight || sorter.Group(m) != sorter.Group(prev) {
+		return true
+	}
+	for _, dep := range m.DependsOn {
+		if currentRefs[dep] {
+			return true
+		}
+	}
+	return false
+}
+
+// phaseHooks splits hooks (already ordered by SortManifests) into phases,
+// cutting on weight and sorter.Group boundaries. Hooks don't carry their
+// helm.sh/depends-on references past SortManifests, so phases here rely on
+// weight/Group alone; hook execution is also serialized per-event by the
+// action package today, so this mainly documents intent for future use.
+func phaseHooks(hooks []*release.Hook, sorter ManifestSorter) [][]*release.Hook {
+	var phases [][]*release.Hook
+	var current []*release.Hook
+
+	for _, h := range hooks {
+		if len(current) > 0 {
+			prev := current[len(current)-1]
+			if h.Weight != prev.Weight || sorter.Group(hookAsManifest(h)) != sorter.Group(hookAsManifest(prev)) {
+				phases = append(phases, current)
+				current = nil
+			}
+		}
+		current = append(current, h)
+	}
+	if len(current) > 0 {
+		phases = append(phases, current)
+	}
+	return phases
+}
@@ -0,0 +1,114 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package releaseutil
+
+import (
+	"testing"
+
+	"helm.sh/helm/v3/pkg/chartutil"
+)
+
+func TestSortManifestsIntoPhases(t *testing.T) {
+	crd := `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.example.com`
+
+	ns := `apiVersion: v1
+kind: Namespace
+metadata:
+  name: example`
+
+	svcAccount := `apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: example`
+
+	deployA := `apiVersion: v1
+kind: Deployment
+metadata:
+  name: a`
+
+	deployB := `apiVersion: v1
+kind: Deployment
+metadata:
+  name: b
+  annotations:
+    "helm.sh/depends-on": "Deployment/a"`
+
+	input := map[string]string{
+		"crd":         crd,
+		"ns":          ns,
+		"svc-account": svcAccount,
+		"deploy-a":    deployA,
+		"deploy-b":    deployB,
+	}
+
+	phases, hookPhases, err := SortManifestsIntoPhases(input, chartutil.VersionSet{"v1", "apiextensions.k8s.io/v1"}, InstallOrder)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if len(hookPhases) != 0 {
+		t.Fatalf("Expected no hook phases, got %d", len(hookPhases))
+	}
+
+	// Expected phases, in InstallOrder's own Kind sequence: [ns],
+	// [svc-account], [crd], [deploy-a], [deploy-b]. deploy-a and deploy-b
+	// share a kind-group but deploy-b depends on deploy-a, so they can't
+	// share a phase.
+	if len(phases) != 5 {
+		names := make([][]string, len(phases))
+		for i, phase := range phases {
+			for _, m := range phase {
+				names[i] = append(names[i], m.Head.Metadata.Name)
+			}
+		}
+		t.Fatalf("Expected 5 phases, got %d: %v", len(phases), names)
+	}
+
+	wantNames := []string{"example", "example", "widgets.example.com", "a", "b"}
+	for i, phase := range phases {
+		if len(phase) != 1 {
+			t.Fatalf("Expected phase %d to have exactly 1 manifest, got %d", i, len(phase))
+		}
+		if got := phase[0].Head.Metadata.Name; got != wantNames[i] {
+			t.Errorf("phase %d: expected manifest %q, got %q", i, wantNames[i], got)
+		}
+	}
+}
+
+func TestSortManifestsIntoPhasesGroupsIndependentManifests(t *testing.T) {
+	deployA := `apiVersion: v1
+kind: Deployment
+metadata:
+  name: a`
+
+	deployB := `apiVersion: v1
+kind: Deployment
+metadata:
+  name: b`
+
+	input := map[string]string{"deploy-a": deployA, "deploy-b": deployB}
+
+	phases, _, err := SortManifestsIntoPhases(input, chartutil.VersionSet{"v1"}, InstallOrder)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if len(phases) != 1 || len(phases[0]) != 2 {
+		t.Fatalf("Expected a single phase with both manifests, got %v", phases)
+	}
+}